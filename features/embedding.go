@@ -0,0 +1,26 @@
+// Copyright 2015 The dpar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package features
+
+import (
+	"github.com/danieldk/dpar/system"
+)
+
+// EmbeddingFeatureGenerator is implemented by feature generators that
+// can, besides hashed sparse features, also produce dense embedding
+// table indices for each addressed position. NeuralGuide uses this to
+// look up TOKEN/TAG/DEPREL embeddings directly instead of hashing into
+// a fixed-size weight vector.
+type EmbeddingFeatureGenerator interface {
+	FeatureGenerator
+
+	// GenerateEmbeddingIndices fills indices with one embedding index
+	// per address that the generator was configured with, in a fixed
+	// order matching the layout NeuralGuide was trained on. An address
+	// that is not addressable in configuration (e.g. a left dependent
+	// that does not exist) is filled with 0, the reserved
+	// padding/out-of-vocabulary index.
+	GenerateEmbeddingIndices(configuration *system.Configuration, indices []int32)
+}
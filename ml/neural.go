@@ -0,0 +1,137 @@
+// Copyright 2015 The dpar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ml
+
+import (
+	"math"
+
+	"github.com/danieldk/dpar/features"
+	"github.com/danieldk/dpar/nn"
+	"github.com/danieldk/dpar/system"
+)
+
+var _ system.Guide = &NeuralGuide{}
+var _ system.ScoringGuide = &NeuralGuide{}
+
+// TransitionNumberer assigns a stable, dense index to every distinct
+// transition it is asked to number, keyed on its serialized string
+// form. NeuralGuide's output layer addresses transitions by this
+// index rather than depending on golinear's own label numbering, so
+// that it has no golinear dependency at all.
+type TransitionNumberer struct {
+	serializer system.TransitionSerializer
+	toIndex    map[string]int32
+	toLabel    []system.Transition
+}
+
+// NewTransitionNumberer creates an empty TransitionNumberer that
+// serializes transitions with serializer.
+func NewTransitionNumberer(serializer system.TransitionSerializer) *TransitionNumberer {
+	return &TransitionNumberer{serializer: serializer, toIndex: make(map[string]int32)}
+}
+
+// Number returns t's index, minting a new one the first time a
+// transition with t's serialized form is seen.
+func (n *TransitionNumberer) Number(t system.Transition) (int32, error) {
+	s, err := n.serializer.SerializeTransition(t)
+	if err != nil {
+		return 0, err
+	}
+
+	if idx, ok := n.toIndex[s]; ok {
+		return idx, nil
+	}
+
+	idx := int32(len(n.toLabel))
+	n.toIndex[s] = idx
+	n.toLabel = append(n.toLabel, t)
+	return idx, nil
+}
+
+// Label returns the transition previously numbered index, or nil if
+// index has not been assigned.
+func (n *TransitionNumberer) Label(index int32) system.Transition {
+	if index < 0 || int(index) >= len(n.toLabel) {
+		return nil
+	}
+
+	return n.toLabel[index]
+}
+
+// Len returns the number of distinct transitions that have been
+// numbered.
+func (n *TransitionNumberer) Len() int {
+	return len(n.toLabel)
+}
+
+// NeuralGuide is a system.Guide backed by a small feed-forward network
+// (nn.Model) rather than golinear.Model. Instead of hashing sparse
+// features like HashingSVMGuide, it looks up one dense embedding per
+// addressed position from featureGenerator and lets the network
+// combine and score them.
+type NeuralGuide struct {
+	model            *nn.Model
+	featureGenerator features.EmbeddingFeatureGenerator
+	layers           []features.Layer
+	transitions      *TransitionNumberer
+}
+
+// NewNeuralGuide constructs a NeuralGuide. layers gives the feature
+// layer (TOKEN/TAG/DEPREL) of each slot that featureGenerator fills in
+// GenerateEmbeddingIndices, in the fixed order model was trained with;
+// transitions maps the network's output row back to a Transition.
+func NewNeuralGuide(model *nn.Model, featureGenerator features.EmbeddingFeatureGenerator,
+	layers []features.Layer, transitions *TransitionNumberer) *NeuralGuide {
+	return &NeuralGuide{model, featureGenerator, layers, transitions}
+}
+
+func (g *NeuralGuide) BestTransition(configuration *system.Configuration) system.Transition {
+	var bestLabel system.Transition
+	bestScore := math.Inf(-1)
+
+	for _, scored := range g.TransitionScores(configuration) {
+		if scored.Score < bestScore {
+			continue
+		}
+
+		bestScore = scored.Score
+		bestLabel = scored.Transition
+	}
+
+	return bestLabel
+}
+
+// TransitionScores runs the network forward and returns its softmax
+// probability for every transition that is possible in configuration.
+func (g *NeuralGuide) TransitionScores(configuration *system.Configuration) []system.ScoredTransition {
+	probs := g.model.Predict(g.embeddingIndices(configuration))
+
+	scores := make([]system.ScoredTransition, 0, len(probs))
+	for idx, prob := range probs {
+		label := g.transitions.Label(int32(idx))
+		if label == nil || !label.IsPossible(*configuration) {
+			continue
+		}
+
+		scores = append(scores, system.ScoredTransition{Transition: label, Score: prob})
+	}
+
+	return scores
+}
+
+// embeddingIndices extracts configuration's embedding indices through
+// featureGenerator and pairs each with the feature layer it was
+// configured for, in the form Model.Predict requires.
+func (g *NeuralGuide) embeddingIndices(configuration *system.Configuration) []nn.LayerIndex {
+	indices := make([]int32, len(g.layers))
+	g.featureGenerator.GenerateEmbeddingIndices(configuration, indices)
+
+	layerIndices := make([]nn.LayerIndex, len(indices))
+	for i, index := range indices {
+		layerIndices[i] = nn.LayerIndex{Layer: int(g.layers[i]), Index: index}
+	}
+
+	return layerIndices
+}
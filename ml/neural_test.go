@@ -0,0 +1,72 @@
+// Copyright 2015 The dpar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ml
+
+import (
+	"testing"
+
+	"github.com/danieldk/conllx"
+	"github.com/danieldk/dpar/system"
+)
+
+// TestTransitionNumbererRoundTrip checks that TransitionNumberer
+// assigns distinct, stable indices to distinct transitions, and that
+// Label inverts Number -- the piece of NeuralGuide that maps a
+// network's output row back to the Transition it corresponds to.
+func TestTransitionNumbererRoundTrip(t *testing.T) {
+	ts := system.NewArcStandard()
+
+	tokens := []conllx.Token{*conllx.NewToken(), *conllx.NewToken()}
+	c, err := system.NewConfiguration(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var transitions []system.Transition
+	for trans := range ts.PossibleTransitions(c) {
+		transitions = append(transitions, trans)
+	}
+
+	if len(transitions) < 2 {
+		t.Fatalf("expected at least two possible transitions in the initial configuration, got %d", len(transitions))
+	}
+
+	numberer := NewTransitionNumberer(ts)
+
+	first, err := numberer.Number(transitions[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := numberer.Number(transitions[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first == second {
+		t.Fatalf("distinct transitions were given the same number: %d", first)
+	}
+
+	again, err := numberer.Number(transitions[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if again != first {
+		t.Errorf("renumbering an already-seen transition returned %d, want %d", again, first)
+	}
+
+	if got := numberer.Label(first); got != transitions[0] {
+		t.Errorf("Label(%d) = %v, want %v", first, got, transitions[0])
+	}
+
+	if got := numberer.Label(99); got != nil {
+		t.Errorf("Label of an unassigned index = %v, want nil", got)
+	}
+
+	if numberer.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", numberer.Len())
+	}
+}
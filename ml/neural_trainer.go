@@ -0,0 +1,58 @@
+// Copyright 2015 The dpar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ml
+
+import (
+	"github.com/danieldk/conllx"
+	"github.com/danieldk/dpar/features"
+	"github.com/danieldk/dpar/nn"
+	"github.com/danieldk/dpar/system"
+)
+
+// GenerateTrainingExamples runs oracle greedily over tokens, applying
+// whatever transition it picks at every step, and dumps one
+// nn.TrainingExample per step: the embedding indices featureGenerator
+// extracts for the configuration oracle saw, paired with the
+// transition oracle actually chose. The result is meant to be written
+// out with nn.SaveExamples and trained offline, then reloaded into a
+// NeuralGuide with the same transitions numbering.
+//
+// Since oracle, not a guide under training, drives every step, the
+// dumped examples are the gold derivation; running this against an
+// Oracle that exposes the erroneous configurations a guide under
+// training actually reaches (rather than BestTransition's single gold
+// path) is left to the caller.
+func GenerateTrainingExamples(ts system.TransitionSystem, featureGenerator features.EmbeddingFeatureGenerator,
+	layers []features.Layer, transitions *TransitionNumberer, oracle system.Guide,
+	tokens []conllx.Token) ([]nn.TrainingExample, error) {
+	c, err := system.NewConfiguration(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	var examples []nn.TrainingExample
+	for !ts.IsTerminal(c) {
+		transition := oracle.BestTransition(&c)
+
+		indices := make([]int32, len(layers))
+		featureGenerator.GenerateEmbeddingIndices(&c, indices)
+
+		layerIndices := make([]nn.LayerIndex, len(indices))
+		for i, index := range indices {
+			layerIndices[i] = nn.LayerIndex{Layer: int(layers[i]), Index: index}
+		}
+
+		label, err := transitions.Number(transition)
+		if err != nil {
+			return nil, err
+		}
+
+		examples = append(examples, nn.TrainingExample{Indices: layerIndices, Label: label})
+
+		transition.Apply(&c)
+	}
+
+	return examples, nil
+}
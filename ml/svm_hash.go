@@ -13,6 +13,7 @@ import (
 )
 
 var _ system.Guide = &HashingSVMGuide{}
+var _ system.ScoringGuide = &HashingSVMGuide{}
 
 type HashingSVMGuide struct {
 	model            *golinear.Model
@@ -28,6 +29,26 @@ func NewHashingSVMGuide(model *golinear.Model, featureGenerator features.Feature
 }
 
 func (g *HashingSVMGuide) BestTransition(configuration *system.Configuration) system.Transition {
+	var bestLabel system.Transition
+	bestValue := math.Inf(-1)
+
+	for _, scored := range g.TransitionScores(configuration) {
+		if scored.Score < bestValue {
+			continue
+		}
+
+		bestValue = scored.Score
+		bestLabel = scored.Transition
+	}
+
+	return bestLabel
+}
+
+// TransitionScores returns the model's decision value for every
+// transition that is possible in configuration, so that callers such
+// as BeamParser can rank them instead of only taking the single best
+// one.
+func (g *HashingSVMGuide) TransitionScores(configuration *system.Configuration) []system.ScoredTransition {
 	vecBuilder := features.NewFeatureVectorBuilder()
 	g.featureGenerator.GenerateHashed(configuration, g.hashFunc, vecBuilder)
 	x := vecBuilder.Build()
@@ -47,26 +68,18 @@ func (g *HashingSVMGuide) BestTransition(configuration *system.Configuration) sy
 
 	_, values, _ := g.model.PredictDecisionValuesSlice(x)
 
-	// XXX: large overlap with SVMGuide.Bestsystem.Transition. Factor out!
-
-	var bestLabel system.Transition
-	bestValue := math.Inf(-1)
 	labels := g.model.Labels()
+	scores := make([]system.ScoredTransition, 0, len(values))
 
 	for idx, value := range values {
-		if value < bestValue {
-			continue
-		}
-
 		numLabel := labels[idx]
 		label := g.labelNumberer.Label(numLabel)
 		if !label.IsPossible(*configuration) {
 			continue
 		}
 
-		bestValue = value
-		bestLabel = label
+		scores = append(scores, system.ScoredTransition{Transition: label, Score: value})
 	}
 
-	return bestLabel
+	return scores
 }
@@ -0,0 +1,222 @@
+// Copyright 2015 The dpar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package nn implements inference for small feed-forward networks:
+// embedding lookups, a handful of dense hidden layers, and a softmax
+// output. It is deliberately minimal -- just enough matrix/vector
+// arithmetic to score a parser transition -- so that NeuralGuide does
+// not have to depend on a full tensor library to load and run a model
+// that was trained offline.
+package nn
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"os"
+)
+
+// Activation is a hidden-layer non-linearity.
+type Activation int
+
+const (
+	// Cubic is the cube activation x^3, as used by Chen & Manning
+	// (2014) for transition-based parsing, which was found to combine
+	// three embeddings more effectively than tanh or ReLU in their
+	// setting.
+	Cubic Activation = iota
+	ReLU
+
+	// Identity is the identity function x, used for Model.Output: its
+	// weighted sums are logits that Predict feeds to softmax, and
+	// clipping negative logits with ReLU would corrupt the ranking
+	// softmax depends on.
+	Identity
+)
+
+func (a Activation) apply(x float64) float64 {
+	switch a {
+	case ReLU:
+		if x < 0 {
+			return 0
+		}
+		return x
+	case Identity:
+		return x
+	default:
+		return x * x * x
+	}
+}
+
+// Layer is a single fully-connected layer: output = W*input + bias.
+// Weights is stored row-major with Rows*Cols entries.
+type Layer struct {
+	Weights []float64
+	Bias    []float64
+	Rows    int
+	Cols    int
+}
+
+// Apply computes activation(W*input + bias). It panics if len(input)
+// does not match Cols, since that indicates a model/feature mismatch
+// the caller should not attempt to recover from.
+func (l *Layer) Apply(input []float64, activation Activation) []float64 {
+	if len(input) != l.Cols {
+		panic(fmt.Sprintf("nn: layer expects %d inputs, got %d", l.Cols, len(input)))
+	}
+
+	output := make([]float64, l.Rows)
+	for row := 0; row < l.Rows; row++ {
+		sum := l.Bias[row]
+		base := row * l.Cols
+		for col := 0; col < l.Cols; col++ {
+			sum += l.Weights[base+col] * input[col]
+		}
+		output[row] = activation.apply(sum)
+	}
+
+	return output
+}
+
+// Model is a feed-forward network: a sequence of embedding tables (one
+// per feature layer, e.g. TOKEN/TAG/DEPREL), zero or more hidden
+// layers, and a linear output layer over transitions, read as logits
+// that Predict turns into a softmax distribution.
+type Model struct {
+	// Embeddings holds one table per feature layer. Row 0 of every
+	// table is the padding/out-of-vocabulary embedding.
+	Embeddings [][][]float64
+	Hidden     []Layer
+	Output     Layer
+	Activation Activation
+}
+
+// LoadModel reads a Model previously written by SaveModel.
+func LoadModel(path string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var m Model
+	if err := gob.NewDecoder(f).Decode(&m); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// SaveModel writes m to path using encoding/gob, so that a model
+// trained offline can be reloaded by NeuralGuide without depending on
+// an external serialization format.
+func SaveModel(path string, m *Model) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return gob.NewEncoder(f).Encode(m)
+}
+
+// embeddingAt looks up the embedding for index in feature layer layer,
+// falling back to the padding/out-of-vocabulary row 0 if index is out
+// of range.
+func (m *Model) embeddingAt(layer int, index int32) []float64 {
+	table := m.Embeddings[layer]
+	if index < 0 || int(index) >= len(table) {
+		index = 0
+	}
+
+	return table[index]
+}
+
+// Predict concatenates the embeddings addressed by indices (one
+// lookup per (layer, index) pair, in order), runs them through the
+// hidden layers and the output layer, and returns the resulting
+// softmax distribution over transitions.
+func (m *Model) Predict(indices []LayerIndex) []float64 {
+	var input []float64
+	for _, li := range indices {
+		input = append(input, m.embeddingAt(li.Layer, li.Index)...)
+	}
+
+	hidden := input
+	for i := range m.Hidden {
+		hidden = m.Hidden[i].Apply(hidden, m.Activation)
+	}
+
+	logits := m.Output.Apply(hidden, Identity)
+	return softmax(logits)
+}
+
+// LayerIndex addresses a single embedding lookup: row Index of the
+// table for feature layer Layer.
+type LayerIndex struct {
+	Layer int
+	Index int32
+}
+
+// TrainingExample is one (features, gold transition) sample dumped
+// from an oracle rollout, as produced by ml.GenerateTrainingExamples,
+// for an offline trainer to consume.
+type TrainingExample struct {
+	Indices []LayerIndex
+	Label   int32
+}
+
+// SaveExamples writes examples to path using encoding/gob, mirroring
+// SaveModel, so that examples dumped by a Go trainer entry point can
+// be read back by any offline training script that speaks the same
+// format.
+func SaveExamples(path string, examples []TrainingExample) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return gob.NewEncoder(f).Encode(examples)
+}
+
+// LoadExamples reads TrainingExamples previously written by
+// SaveExamples.
+func LoadExamples(path string) ([]TrainingExample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var examples []TrainingExample
+	if err := gob.NewDecoder(f).Decode(&examples); err != nil {
+		return nil, err
+	}
+
+	return examples, nil
+}
+
+func softmax(logits []float64) []float64 {
+	max := logits[0]
+	for _, l := range logits[1:] {
+		if l > max {
+			max = l
+		}
+	}
+
+	sum := 0.
+	probs := make([]float64, len(logits))
+	for i, l := range logits {
+		p := math.Exp(l - max)
+		probs[i] = p
+		sum += p
+	}
+
+	for i := range probs {
+		probs[i] /= sum
+	}
+
+	return probs
+}
@@ -0,0 +1,47 @@
+// Copyright 2015 The dpar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	"math"
+	"testing"
+)
+
+// TestModelPredictSoftmax checks Predict against a hand-computed
+// softmax over a mix of positive and negative logits, with no hidden
+// layers in between, so that the only thing under test is how the
+// output layer's weighted sums are turned into logits. An output
+// activation that clipped negative values (e.g. ReLU) would turn the
+// -1 logit below into 0, tying it with a true 0 logit instead of
+// ranking it below.
+func TestModelPredictSoftmax(t *testing.T) {
+	model := &Model{
+		Embeddings: [][][]float64{
+			{
+				{0, 0},
+				{1, -1},
+			},
+		},
+		Output: Layer{
+			Weights: []float64{
+				1, 0,
+				0, 1,
+			},
+			Bias: []float64{0, 0},
+			Rows: 2,
+			Cols: 2,
+		},
+		Activation: Cubic,
+	}
+
+	probs := model.Predict([]LayerIndex{{Layer: 0, Index: 1}})
+
+	expected := []float64{0.8807970779778823, 0.11920292202211755}
+	for idx, want := range expected {
+		if math.Abs(probs[idx]-want) > 1e-9 {
+			t.Errorf("probs[%d] = %v, want %v", idx, probs[idx], want)
+		}
+	}
+}
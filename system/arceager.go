@@ -0,0 +1,181 @@
+// Copyright 2015 The dpar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package system
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Archetype transitions + interface validation.
+var archetypeAEShift Transition = aeShift{}
+var archetypeAEReduce Transition = aeReduce{}
+var archetypeAELeftArc Transition = aeLeftArc{"<archetype>"}
+var archetypeAERightArc Transition = aeRightArc{"<archetype>"}
+
+// Assert TransitionSystem/TransitionSerializer conformance.
+var _ TransitionSystem = NewArcEager()
+var _ TransitionSerializer = NewArcEager()
+
+// ArcEager is the arc-eager transition system of Nivre (2003), with
+// the four transitions SHIFT, REDUCE, LEFT-ARC(l) and RIGHT-ARC(l).
+// Unlike ArcStandard it attaches a right dependent to its head as soon
+// as the two are adjacent, rather than waiting until the dependent has
+// collected all of its own dependents; the attached dependent stays on
+// the stack until REDUCE pops it.
+type ArcEager struct {
+	archetypeTransitions TransitionSet
+}
+
+func NewArcEager() *ArcEager {
+	trans := map[Transition]interface{}{
+		archetypeAEShift:    nil,
+		archetypeAEReduce:   nil,
+		archetypeAELeftArc:  nil,
+		archetypeAERightArc: nil,
+	}
+
+	return &ArcEager{trans}
+}
+
+func (ts *ArcEager) IsTerminal(c Configuration) bool {
+	return len(c.Buffer) == 0
+}
+
+func (ts *ArcEager) PossibleTransitions(configuration Configuration) TransitionSet {
+	possible := make(TransitionSet)
+
+	for trans := range ts.archetypeTransitions {
+		if trans.IsPossible(configuration) {
+			possible[trans] = nil
+		}
+	}
+
+	return possible
+}
+
+func (ts *ArcEager) SerializeTransition(t Transition) (string, error) {
+	switch t := t.(type) {
+	case aeLeftArc:
+		return fmt.Sprintf("LEFT_ARC %s", t.relation), nil
+	case aeRightArc:
+		return fmt.Sprintf("RIGHT_ARC %s", t.relation), nil
+	case aeReduce:
+		return "REDUCE", nil
+	case aeShift:
+		return "SHIFT", nil
+	default:
+		return "", fmt.Errorf("Not a transition of the arc-eager system: %T", t)
+	}
+}
+
+func (ts *ArcEager) DeserializeTransition(transString string) (Transition, error) {
+	parts := strings.SplitN(transString, " ", 2)
+
+	if len(parts) == 0 {
+		return nil, errors.New("Empty transition")
+	}
+
+	switch parts[0] {
+	default:
+		return nil, fmt.Errorf("Unknown transition: %s", parts[0])
+	case "LEFT_ARC":
+		if len(parts) == 1 {
+			return nil, errors.New("Left-Arc transition requires label argument")
+		}
+		return aeLeftArc{parts[1]}, nil
+	case "RIGHT_ARC":
+		if len(parts) == 1 {
+			return nil, errors.New("Right-Arc transition requires label argument")
+		}
+		return aeRightArc{parts[1]}, nil
+	case "REDUCE":
+		return aeReduce{}, nil
+	case "SHIFT":
+		return aeShift{}, nil
+	}
+}
+
+// aeLeftArc attaches the stack tip as a dependent of the buffer head.
+// It requires that the stack tip does not already have a head: once
+// attached, a token is immediately popped and can never be revisited,
+// so assigning it a second head would be unrecoverable.
+type aeLeftArc struct {
+	relation string
+}
+
+func (l aeLeftArc) IsPossible(c Configuration) bool {
+	stackSize := len(c.Stack)
+	if stackSize == 0 || len(c.Buffer) == 0 || c.Stack[stackSize-1] == 0 {
+		return false
+	}
+
+	_, hasHead := c.Head(c.Stack[stackSize-1])
+	return !hasHead
+}
+
+func (l aeLeftArc) Apply(c *Configuration) {
+	stackSize := len(c.Stack)
+	head := c.Buffer[0]
+	dependant := c.Stack[stackSize-1]
+	dependency := Dependency{head, l.relation, dependant}
+
+	c.AddDependency(&dependency)
+	c.Stack = c.Stack[:stackSize-1]
+}
+
+// aeRightArc attaches the buffer head as a dependent of the stack tip,
+// then shifts it onto the stack so that it, in turn, can collect its
+// own dependents before being reduced.
+type aeRightArc struct {
+	relation string
+}
+
+func (r aeRightArc) IsPossible(c Configuration) bool {
+	return len(c.Stack) != 0 && len(c.Buffer) != 0
+}
+
+func (r aeRightArc) Apply(c *Configuration) {
+	stackSize := len(c.Stack)
+	head := c.Stack[stackSize-1]
+	dependant := c.Buffer[0]
+	dependency := Dependency{head, r.relation, dependant}
+
+	c.AddDependency(&dependency)
+	c.Stack = append(c.Stack, dependant)
+	c.Buffer = c.Buffer[1:]
+}
+
+// aeReduce pops the stack tip. It requires the stack tip to already
+// have a head, since popping it without one would leave it permanently
+// unattached.
+type aeReduce struct{}
+
+func (r aeReduce) IsPossible(c Configuration) bool {
+	stackSize := len(c.Stack)
+	if stackSize == 0 {
+		return false
+	}
+
+	_, hasHead := c.Head(c.Stack[stackSize-1])
+	return hasHead
+}
+
+func (r aeReduce) Apply(c *Configuration) {
+	c.Stack = c.Stack[:len(c.Stack)-1]
+}
+
+type aeShift struct{}
+
+func (s aeShift) IsPossible(c Configuration) bool {
+	return len(c.Buffer) != 0
+}
+
+func (s aeShift) Apply(c *Configuration) {
+	token := c.Buffer[0]
+	c.Buffer = c.Buffer[1:]
+	c.Stack = append(c.Stack, token)
+}
@@ -0,0 +1,187 @@
+// Copyright 2015 The dpar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package system
+
+// Oracle is a guide that, unlike the deterministic ArcStandardOracle,
+// does not commit to a single transition. Instead it exposes every
+// transition that is still zero-cost given the current configuration,
+// which may already disagree with the gold tree. Training against the
+// full zero-cost set (rather than a single gold path) is what lets a
+// guide learn to recover from its own mistakes instead of only ever
+// seeing perfectly-parsed prefixes.
+type Oracle interface {
+	ZeroCost(configuration *Configuration) TransitionSet
+}
+
+var _ Oracle = &ArcEagerDynamicOracle{}
+var _ Guide = &ArcEagerDynamicOracle{}
+
+// ArcEagerDynamicOracle is the dynamic oracle of
+// Goldberg & Nivre (2012) for the arc-eager transition system. Cost is
+// computed per transition as the number of gold arcs that the
+// transition would make permanently unreachable (the tree is
+// arc-decomposable, so this can be done one arc at a time rather than
+// by searching the whole derivation space); a transition is zero-cost
+// iff that count is zero.
+type ArcEagerDynamicOracle struct {
+	heads map[uint]Dependency
+}
+
+func NewArcEagerDynamicOracle(goldDependencies DependencySet) *ArcEagerDynamicOracle {
+	return &ArcEagerDynamicOracle{goldDependencies.CreateDependentHeadMapping()}
+}
+
+func (o *ArcEagerDynamicOracle) goldHead(token uint) (uint, bool) {
+	dep, ok := o.heads[token]
+	if !ok {
+		return 0, false
+	}
+
+	return dep.Head, true
+}
+
+// ZeroCost returns every transition that is both possible and
+// zero-cost in configuration.
+func (o *ArcEagerDynamicOracle) ZeroCost(c *Configuration) TransitionSet {
+	zero := make(TransitionSet)
+
+	if t := (aeShift{}); t.IsPossible(*c) && o.shiftCost(c) == 0 {
+		zero[t] = nil
+	}
+
+	if t := (aeReduce{}); t.IsPossible(*c) && o.reduceCost(c) == 0 {
+		zero[t] = nil
+	}
+
+	stackSize := len(c.Stack)
+	if stackSize != 0 && len(c.Buffer) != 0 {
+		s := c.Stack[stackSize-1]
+		b := c.Buffer[0]
+
+		la := aeLeftArc{o.heads[s].Relation}
+		if la.IsPossible(*c) && o.leftArcCost(c) == 0 {
+			zero[la] = nil
+		}
+
+		ra := aeRightArc{o.heads[b].Relation}
+		if ra.IsPossible(*c) && o.rightArcCost(c) == 0 {
+			zero[ra] = nil
+		}
+	}
+
+	return zero
+}
+
+// BestTransition picks a single zero-cost transition, preferring
+// LEFT-ARC, then RIGHT-ARC, then REDUCE, then SHIFT. This lets the
+// dynamic oracle double as an ordinary Guide, e.g. to produce a
+// canonical training derivation or as a greedy baseline.
+func (o *ArcEagerDynamicOracle) BestTransition(c *Configuration) Transition {
+	zero := o.ZeroCost(c)
+
+	for t := range zero {
+		if _, ok := t.(aeLeftArc); ok {
+			return t
+		}
+	}
+
+	for t := range zero {
+		if _, ok := t.(aeRightArc); ok {
+			return t
+		}
+	}
+
+	if _, ok := zero[aeReduce{}]; ok {
+		return aeReduce{}
+	}
+
+	return aeShift{}
+}
+
+// leftArcCost counts the gold arcs lost by attaching the stack tip s
+// to the buffer head b: s getting the wrong head (if b is not
+// actually s's gold head) and any gold dependents of s that are still
+// in the buffer behind b, which s can no longer acquire once popped.
+func (o *ArcEagerDynamicOracle) leftArcCost(c *Configuration) int {
+	stackSize := len(c.Stack)
+	s := c.Stack[stackSize-1]
+	b := c.Buffer[0]
+
+	cost := 0
+	if head, ok := o.goldHead(s); !ok || head != b {
+		cost++
+	}
+
+	for _, k := range c.Buffer[1:] {
+		if head, ok := o.goldHead(k); ok && head == s {
+			cost++
+		}
+	}
+
+	return cost
+}
+
+// rightArcCost counts the gold arcs lost by attaching the buffer head
+// b to the stack tip s: b getting the wrong head, and any gold
+// dependents of b that are still on the stack, which b can no longer
+// acquire: RIGHT-ARC moves b out of the buffer, and only the buffer
+// head can take the stack tip as a LEFT-ARC dependent.
+func (o *ArcEagerDynamicOracle) rightArcCost(c *Configuration) int {
+	stackSize := len(c.Stack)
+	s := c.Stack[stackSize-1]
+	b := c.Buffer[0]
+
+	cost := 0
+	if head, ok := o.goldHead(b); !ok || head != s {
+		cost++
+	}
+
+	for _, k := range c.Stack[:stackSize-1] {
+		if head, ok := o.goldHead(k); ok && head == b {
+			cost++
+		}
+	}
+
+	return cost
+}
+
+// shiftCost counts the gold arcs between the buffer head b and any
+// token currently on the stack. Shifting b moves it out of the buffer
+// onto the stack, above every stack token, and arcs are only built
+// between the stack tip and the buffer head, so no arc between b and
+// those tokens can be built any more.
+func (o *ArcEagerDynamicOracle) shiftCost(c *Configuration) int {
+	b := c.Buffer[0]
+	cost := 0
+
+	for _, k := range c.Stack {
+		if head, ok := o.goldHead(k); ok && head == b {
+			cost++
+		}
+
+		if head, ok := o.goldHead(b); ok && head == k {
+			cost++
+		}
+	}
+
+	return cost
+}
+
+// reduceCost counts the gold dependents of the stack tip s that are
+// still in the buffer. Reducing s pops it for good, so it can no
+// longer acquire them.
+func (o *ArcEagerDynamicOracle) reduceCost(c *Configuration) int {
+	stackSize := len(c.Stack)
+	s := c.Stack[stackSize-1]
+
+	cost := 0
+	for _, k := range c.Buffer {
+		if head, ok := o.goldHead(k); ok && head == s {
+			cost++
+		}
+	}
+
+	return cost
+}
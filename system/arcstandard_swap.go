@@ -0,0 +1,251 @@
+// Copyright 2015 The dpar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package system
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Archetype transitions + interface validation.
+var archetypeASSwap Transition = asSwap{}
+
+// Assert TransitionSystem/TransitionSerializer conformance.
+var _ TransitionSystem = NewArcStandardSwap()
+var _ TransitionSerializer = NewArcStandardSwap()
+
+// ArcStandardSwap extends ArcStandard with the SWAP transition of
+// Nivre & Nilsson (2009): it moves the stack's second element back
+// onto the front of the buffer so that it can be re-attached after
+// tokens between it and its head have been shifted. Unlike plain
+// ArcStandard, this lets the system build non-projective trees.
+type ArcStandardSwap struct {
+	archetypeTransitions TransitionSet
+}
+
+func NewArcStandardSwap() *ArcStandardSwap {
+	trans := map[Transition]interface{}{
+		archetypeASShift:    nil,
+		archetypeASLeftArc:  nil,
+		archetypeASRightArc: nil,
+		archetypeASSwap:     nil,
+	}
+
+	return &ArcStandardSwap{trans}
+}
+
+func (ts *ArcStandardSwap) IsTerminal(c Configuration) bool {
+	return len(c.Buffer) == 0
+}
+
+func (ts *ArcStandardSwap) PossibleTransitions(configuration Configuration) TransitionSet {
+	possible := make(TransitionSet)
+
+	for trans := range ts.archetypeTransitions {
+		if trans.IsPossible(configuration) {
+			possible[trans] = nil
+		}
+	}
+
+	return possible
+}
+
+func (ts *ArcStandardSwap) SerializeTransition(t Transition) (string, error) {
+	switch t := t.(type) {
+	case asLeftArc:
+		return fmt.Sprintf("LEFT_ARC %s", t.relation), nil
+	case asRightArc:
+		return fmt.Sprintf("RIGHT_ARC %s", t.relation), nil
+	case asShift:
+		return "SHIFT", nil
+	case asSwap:
+		return "SWAP", nil
+	default:
+		return "", fmt.Errorf("Not a transition of the arc-standard-swap system: %T", t)
+	}
+}
+
+func (ts *ArcStandardSwap) DeserializeTransition(transString string) (Transition, error) {
+	parts := strings.SplitN(transString, " ", 2)
+
+	if len(parts) == 0 {
+		return nil, errors.New("Empty transition")
+	}
+
+	switch parts[0] {
+	default:
+		return nil, fmt.Errorf("Unknown transition: %s", parts[0])
+	case "LEFT_ARC":
+		if len(parts) == 1 {
+			return nil, errors.New("Left-Arc transition requires label argument")
+		}
+		return asLeftArc{parts[1]}, nil
+	case "RIGHT_ARC":
+		if len(parts) == 1 {
+			return nil, errors.New("Right-Arc transition requires label argument")
+		}
+		return asRightArc{parts[1]}, nil
+	case "SHIFT":
+		return asShift{}, nil
+	case "SWAP":
+		return asSwap{}, nil
+	}
+}
+
+// asSwap moves the stack's second-from-top element (s1) back onto the
+// front of the buffer, leaving the stack tip (s0) in place. It is the
+// only ArcStandardSwap transition that can reorder tokens relative to
+// their sentence position, which is what lets the system build
+// non-projective trees.
+type asSwap struct{}
+
+func (s asSwap) IsPossible(c Configuration) bool {
+	stackSize := len(c.Stack)
+	if stackSize < 2 {
+		return false
+	}
+
+	s0 := c.Stack[stackSize-1]
+	s1 := c.Stack[stackSize-2]
+
+	// SWAP is only permitted when s1 and s0 are still in their normal
+	// sentence order (s1 precedes s0): once a prior SWAP has already
+	// moved s1 after s0, swapping that same pair again would just undo
+	// it and loop forever, so the precondition excludes it.
+	return s1 != 0 && s1 < s0
+}
+
+func (s asSwap) Apply(c *Configuration) {
+	stackSize := len(c.Stack)
+	s1 := c.Stack[stackSize-2]
+
+	c.Stack = append(c.Stack[:stackSize-2], c.Stack[stackSize-1])
+	c.Buffer = append([]uint{s1}, c.Buffer...)
+}
+
+// ArcStandardSwapOracle is a static oracle for ArcStandardSwap. It
+// ranks every token by the projective order of the gold tree -- the
+// order in which an in-order traversal of the tree visits them, which
+// matches sentence order exactly when the tree is projective -- and
+// prefers SWAP whenever the stack holds two tokens out of that order,
+// falling back to the same LEFT-ARC/RIGHT-ARC/SHIFT choices as
+// ArcStandardOracle otherwise.
+type ArcStandardSwapOracle struct {
+	dependentHeadMapping map[uint]Dependency
+	projectiveRank       map[uint]int
+}
+
+func NewArcStandardSwapOracle(goldDependencies DependencySet) Guide {
+	heads := goldDependencies.CreateDependentHeadMapping()
+
+	oracle := ArcStandardSwapOracle{
+		dependentHeadMapping: heads,
+		projectiveRank:       projectiveRank(heads),
+	}
+
+	return &oracle
+}
+
+func (o *ArcStandardSwapOracle) BestTransition(c *Configuration) Transition {
+	stackSize := len(c.Stack)
+	if stackSize >= 2 {
+		s0 := c.Stack[stackSize-1]
+		s1 := c.Stack[stackSize-2]
+
+		swap := asSwap{}
+		if swap.IsPossible(*c) && o.projectiveRank[s1] > o.projectiveRank[s0] {
+			return swap
+		}
+	}
+
+	if stackSize != 0 {
+		stackTip := c.Stack[stackSize-1]
+		bufferHead := c.Buffer[0]
+
+		la := asLeftArc{o.dependentHeadMapping[stackTip].Relation}
+		if la.IsPossible(*c) && o.dependentHeadMapping[stackTip].Head == bufferHead {
+			return la
+		}
+
+		ra := asRightArc{o.dependentHeadMapping[bufferHead].Relation}
+		if ra.IsPossible(*c) && o.dependentHeadMapping[bufferHead].Head == stackTip &&
+			!o.neededForAttachment(c, bufferHead) {
+			return ra
+		}
+	}
+
+	return asShift{}
+}
+
+func (o *ArcStandardSwapOracle) neededForAttachment(c *Configuration, token uint) bool {
+	for _, bufToken := range c.Buffer {
+		if o.dependentHeadMapping[bufToken].Head == token {
+			return true
+		}
+	}
+
+	return false
+}
+
+// projectiveRank numbers every token (plus the root, 0) by the
+// position an in-order traversal of the gold tree visits it at:
+// children that precede their head in the sentence are visited before
+// it, children that follow are visited after. The result matches
+// plain sentence order exactly for a projective tree; the tokens
+// where it diverges are exactly the ones ArcStandardSwapOracle should
+// prefer SWAP for. A token that takes part in no gold arc is not
+// numbered, so looking it up yields 0, the root's rank.
+func projectiveRank(heads map[uint]Dependency) map[uint]int {
+	children := make(map[uint][]uint)
+
+	for dependent, dep := range heads {
+		children[dep.Head] = append(children[dep.Head], dependent)
+	}
+
+	// A head without a gold head of its own is ranked as a dependent of
+	// the root, as the oracle's zero-value head lookup attaches it.
+	for head := range children {
+		if _, ok := heads[head]; !ok && head != 0 {
+			children[0] = append(children[0], head)
+		}
+	}
+
+	for head := range children {
+		sort.Slice(children[head], func(i, j int) bool {
+			return children[head][i] < children[head][j]
+		})
+	}
+
+	var order []uint
+	appendProjectiveOrder(0, children, &order)
+
+	rank := make(map[uint]int, len(order))
+	for idx, token := range order {
+		rank[token] = idx
+	}
+
+	return rank
+}
+
+// appendProjectiveOrder appends node's in-order traversal to *order:
+// its lower-numbered children, then node itself, then its
+// higher-numbered children, each child recursively expanded the same
+// way.
+func appendProjectiveOrder(node uint, children map[uint][]uint, order *[]uint) {
+	kids := children[node]
+
+	idx := 0
+	for ; idx < len(kids) && kids[idx] < node; idx++ {
+		appendProjectiveOrder(kids[idx], children, order)
+	}
+
+	*order = append(*order, node)
+
+	for ; idx < len(kids); idx++ {
+		appendProjectiveOrder(kids[idx], children, order)
+	}
+}
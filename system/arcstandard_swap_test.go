@@ -0,0 +1,78 @@
+// Copyright 2015 The dpar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package system
+
+import (
+	"testing"
+
+	"github.com/danieldk/conllx"
+)
+
+func TestArcStandardSwapSystem(t *testing.T) {
+	testSystem(t, NewArcStandardSwap(), NewArcStandardSwapOracle)
+}
+
+// TestArcStandardSwapNonProjective derives a genuinely non-projective
+// tree -- token 1 attaches across tokens 2 and 3, which are not its
+// descendants -- by hand, using two SWAPs to reorder the stack so the
+// crossing arc can still be built left-to-right, then checks that
+// NewArcStandardSwapOracle's SWAP/rank-driven choices reconstruct that
+// same tree through a real GreedyParser derivation. testSystem only
+// exercises the shared projective fixture, so without this, neither
+// asSwap.IsPossible nor the oracle's projectiveRank comparison is ever
+// run against data that actually requires a SWAP.
+func TestArcStandardSwapNonProjective(t *testing.T) {
+	tokens := []conllx.Token{*conllx.NewToken(), *conllx.NewToken(), *conllx.NewToken(), *conllx.NewToken()}
+
+	goldConfig, err := NewConfiguration(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// SHIFT, SHIFT leave 1 and 2 on the stack.
+	asShift{}.Apply(&goldConfig)
+	asShift{}.Apply(&goldConfig)
+
+	// SWAP sends 1 back to the buffer, leaving 2 on the stack below it;
+	// shifting 1 and then 3 puts 3 on top of 1.
+	asSwap{}.Apply(&goldConfig)
+	asShift{}.Apply(&goldConfig)
+	asShift{}.Apply(&goldConfig)
+
+	// A second SWAP sends 1 past 3 as well; shifting it back leaves 1
+	// on the stack tip with 4 at the head of the buffer.
+	asSwap{}.Apply(&goldConfig)
+	asShift{}.Apply(&goldConfig)
+
+	// 1 attaches to 4, crossing over 2 and 3, which are left attached
+	// to nothing else in this derivation -- a non-projective arc.
+	asLeftArc{"a"}.Apply(&goldConfig)
+	asShift{}.Apply(&goldConfig)
+
+	gold := goldConfig.Dependencies()
+	goldHeads := gold.CreateDependentHeadMapping()
+
+	dep, ok := goldHeads[1]
+	if !ok || dep.Head != 4 {
+		t.Fatalf("test setup: token 1 = %+v (ok=%v), want Head=4", dep, ok)
+	}
+
+	if isProjectiveArc(dep.Head, 1, goldHeads) {
+		t.Fatal("test setup: arc 4 -> 1 should be non-projective")
+	}
+
+	oracle := NewArcStandardSwapOracle(gold)
+	parser := NewGreedyParser(NewArcStandardSwap(), oracle)
+
+	predicted, err := parser.Parse(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !dependenciesEqual(predicted, gold) {
+		t.Errorf("ArcStandardSwap parse of a non-projective tree = %+v, want %+v",
+			predicted.CreateDependentHeadMapping(), goldHeads)
+	}
+}
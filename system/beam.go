@@ -0,0 +1,244 @@
+// Copyright 2015 The dpar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package system
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/danieldk/conllx"
+)
+
+// ScoredTransition pairs a transition with the score a guide assigned
+// to applying it in some configuration.
+type ScoredTransition struct {
+	Transition Transition
+	Score      float64
+}
+
+// ScoringGuide is a Guide that can additionally score every transition
+// that is possible in a configuration, rather than only returning the
+// single best one. BeamParser requires this to keep several hypotheses
+// alive instead of committing to one transition per step.
+type ScoringGuide interface {
+	Guide
+
+	TransitionScores(configuration *Configuration) []ScoredTransition
+}
+
+var _ Parser = &BeamParser{}
+
+// beamHypothesis is a single candidate derivation carried through the
+// beam, together with its cumulative score.
+type beamHypothesis struct {
+	configuration Configuration
+	score         float64
+}
+
+// BeamParser performs beam search over transition sequences: at every
+// step it keeps the beamSize highest-scoring configurations rather
+// than committing to the single best transition like GreedyParser. A
+// beam width of one makes BeamParser behave identically to
+// GreedyParser.
+type BeamParser struct {
+	transitionSystem TransitionSystem
+	guide            ScoringGuide
+	beamSize         int
+}
+
+// NewBeamParser constructs a BeamParser that decodes with the given
+// transition system and scoring guide, keeping at most beamSize
+// hypotheses at every step. A beamSize below one is raised to one,
+// which degrades the search to greedy decoding.
+func NewBeamParser(ts TransitionSystem, guide ScoringGuide, beamSize int) *BeamParser {
+	if beamSize < 1 {
+		beamSize = 1
+	}
+
+	return &BeamParser{ts, guide, beamSize}
+}
+
+func (p *BeamParser) Parse(tokens []conllx.Token) (DependencySet, error) {
+	c, err := NewConfiguration(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parseConfiguration(&c), nil
+}
+
+func (p *BeamParser) parseConfiguration(c *Configuration) DependencySet {
+	beam := []beamHypothesis{{*c, 0}}
+
+	for !p.beamIsTerminal(beam) {
+		beam = p.step(beam)
+	}
+
+	return p.best(beam).configuration.Dependencies()
+}
+
+func (p *BeamParser) beamIsTerminal(beam []beamHypothesis) bool {
+	for _, hyp := range beam {
+		if !p.transitionSystem.IsTerminal(hyp.configuration) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *BeamParser) best(beam []beamHypothesis) beamHypothesis {
+	best := beam[0]
+	for _, hyp := range beam[1:] {
+		if hyp.score > best.score {
+			best = hyp
+		}
+	}
+
+	return best
+}
+
+// step expands every non-terminal hypothesis in the beam by one
+// transition each and prunes the result back down to beamSize
+// candidates, highest score first. Hypotheses that already reached a
+// terminal configuration are carried over unchanged so that shorter
+// derivations can still compete with longer ones.
+func (p *BeamParser) step(beam []beamHypothesis) []beamHypothesis {
+	var candidates []beamHypothesis
+
+	for _, hyp := range beam {
+		if p.transitionSystem.IsTerminal(hyp.configuration) {
+			candidates = append(candidates, hyp)
+			continue
+		}
+
+		for _, scored := range p.guide.TransitionScores(&hyp.configuration) {
+			if !scored.Transition.IsPossible(hyp.configuration) {
+				continue
+			}
+
+			next := hyp.configuration.clone()
+			scored.Transition.Apply(&next)
+			candidates = append(candidates, beamHypothesis{next, hyp.score + scored.Score})
+		}
+	}
+
+	// The guides' BestTransition (e.g. HashingSVMGuide's) keeps the last
+	// of several equally-scored transitions. Reversing the candidates
+	// before a stable sort lets the last one found win a tie here too,
+	// which keeps a beam width of one equivalent to GreedyParser.
+	for i, j := 0, len(candidates)-1; i < j; i, j = i+1, j-1 {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	if len(candidates) > p.beamSize {
+		candidates = candidates[:p.beamSize]
+	}
+
+	return candidates
+}
+
+// clone returns an independent copy of the configuration so that
+// branching the beam does not let sibling hypotheses alias each
+// other's stack or buffer.
+func (c Configuration) clone() Configuration {
+	clone := c
+	clone.Stack = append([]uint(nil), c.Stack...)
+	clone.Buffer = append([]uint(nil), c.Buffer...)
+	return clone
+}
+
+// EarlyUpdateFunc is invoked by TrainBeam after every search step. step
+// is the zero-based transition index, goldInBeam reports whether the
+// gold-derived configuration is still present in the beam, and
+// beamBest is the beam's current best hypothesis. Returning true stops
+// the search early, which is the "early update" of Collins & Roark:
+// once the gold path has fallen off the beam, running the remaining
+// transitions teaches the guide nothing it can act on.
+type EarlyUpdateFunc func(step int, goldInBeam bool, beamBest DependencySet) bool
+
+// TrainBeam runs beam search while tracking the transition sequence
+// produced by oracle, calling update after every step so a trainer can
+// implement early update and structured-perceptron-style weight
+// updates against whichever hypothesis currently leads the beam. It
+// returns the gold and predicted dependency sets at the point training
+// stopped, and whether that stop was an early update (as opposed to
+// reaching a terminal configuration normally).
+func (p *BeamParser) TrainBeam(tokens []conllx.Token, oracle Guide, update EarlyUpdateFunc) (gold, predicted DependencySet, early bool, err error) {
+	c, err := NewConfiguration(tokens)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	goldConfiguration := c.clone()
+	beam := []beamHypothesis{{c.clone(), 0}}
+
+	for step := 0; !p.transitionSystem.IsTerminal(goldConfiguration); step++ {
+		oracle.BestTransition(&goldConfiguration).Apply(&goldConfiguration)
+
+		if !p.beamIsTerminal(beam) {
+			beam = p.step(beam)
+		}
+
+		goldInBeam := p.beamContains(beam, goldConfiguration)
+		best := p.best(beam)
+
+		if update != nil && update(step, goldInBeam, best.configuration.Dependencies()) {
+			return goldConfiguration.Dependencies(), best.configuration.Dependencies(), true, nil
+		}
+
+		if !goldInBeam {
+			return goldConfiguration.Dependencies(), best.configuration.Dependencies(), true, nil
+		}
+	}
+
+	best := p.best(beam)
+	return goldConfiguration.Dependencies(), best.configuration.Dependencies(), false, nil
+}
+
+// beamContains reports whether some hypothesis in beam is in exactly
+// c's configuration: same stack and buffer shape *and* the same
+// dependency arcs and labels assigned so far. Comparing stack/buffer
+// shape alone is not enough -- a wrong-label LeftArc/RightArc leaves
+// the stack and buffer looking identical to the gold path, so it
+// would never register as falling off the beam.
+func (p *BeamParser) beamContains(beam []beamHypothesis, c Configuration) bool {
+	for _, hyp := range beam {
+		if configurationEqual(hyp.configuration, c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func configurationEqual(a, b Configuration) bool {
+	return stackEqual(a.Stack, b.Stack) && stackEqual(a.Buffer, b.Buffer) &&
+		dependenciesEqual(a.Dependencies(), b.Dependencies())
+}
+
+func stackEqual(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dependenciesEqual reports whether a and b assign every token the
+// same head and relation.
+func dependenciesEqual(a, b DependencySet) bool {
+	return reflect.DeepEqual(a.CreateDependentHeadMapping(), b.CreateDependentHeadMapping())
+}
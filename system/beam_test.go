@@ -0,0 +1,179 @@
+// Copyright 2015 The dpar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package system
+
+import (
+	"testing"
+
+	"github.com/danieldk/conllx"
+)
+
+// oracleScoringGuide turns a deterministic Guide into a ScoringGuide
+// that only ever offers its one chosen transition, so that a
+// BeamParser built around it (at any width) reproduces the guide's
+// own derivation exactly.
+type oracleScoringGuide struct {
+	oracle Guide
+}
+
+var _ ScoringGuide = &oracleScoringGuide{}
+
+func (g *oracleScoringGuide) BestTransition(c *Configuration) Transition {
+	return g.oracle.BestTransition(c)
+}
+
+func (g *oracleScoringGuide) TransitionScores(c *Configuration) []ScoredTransition {
+	return []ScoredTransition{{Transition: g.oracle.BestTransition(c), Score: 1}}
+}
+
+func twoTokenSentence() []conllx.Token {
+	return []conllx.Token{*conllx.NewToken(), *conllx.NewToken()}
+}
+
+// TestConfigurationEqualDetectsLabelMismatch pins the beamContains fix:
+// two configurations with identical stack/buffer shape but different
+// dependency labels must not compare equal, since that is exactly the
+// case a wrong-label LEFT-ARC/RIGHT-ARC produces.
+func TestConfigurationEqualDetectsLabelMismatch(t *testing.T) {
+	tokens := []conllx.Token{*conllx.NewToken(), *conllx.NewToken(), *conllx.NewToken()}
+
+	good, err := NewConfiguration(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bad, err := NewConfiguration(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Shift tokens 1 and 2 onto the stack, leaving 3 in the buffer, so
+	// both configurations end up with the same stack/buffer shape.
+	asShift{}.Apply(&good)
+	asShift{}.Apply(&good)
+	asShift{}.Apply(&bad)
+	asShift{}.Apply(&bad)
+
+	// Attach token 2 to token 3 with different relations.
+	asLeftArc{"nsubj"}.Apply(&good)
+	asLeftArc{"dobj"}.Apply(&bad)
+
+	if !stackEqual(good.Stack, bad.Stack) || !stackEqual(good.Buffer, bad.Buffer) {
+		t.Fatal("test setup: configurations should have matching stack/buffer shape")
+	}
+
+	if configurationEqual(good, bad) {
+		t.Error("configurationEqual treated configurations with different arc labels as equal")
+	}
+}
+
+// TestBeamParserDegradesToGreedyAtWidthOne checks that a beam width of
+// one reproduces a deterministic guide's own derivation exactly, as
+// documented on BeamParser.
+func TestBeamParserDegradesToGreedyAtWidthOne(t *testing.T) {
+	tokens := twoTokenSentence()
+
+	goldConfig, err := NewConfiguration(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Gold derivation: SHIFT, LEFT_ARC(nsubj), RIGHT_ARC(root), SHIFT --
+	// token 1 is token 2's nsubj and token 2 attaches to the root. Every
+	// token gets a gold head, so the oracle (which reads a missing gold
+	// head as an attachment to the root) derives exactly this tree.
+	asShift{}.Apply(&goldConfig)
+	asLeftArc{"nsubj"}.Apply(&goldConfig)
+	asRightArc{"root"}.Apply(&goldConfig)
+	asShift{}.Apply(&goldConfig)
+
+	gold := goldConfig.Dependencies()
+	oracle := NewArcStandardOracle(gold)
+	guide := &oracleScoringGuide{oracle: oracle}
+	parser := NewBeamParser(NewArcStandard(), guide, 1)
+
+	c, err := NewConfiguration(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	predicted := parser.parseConfiguration(&c)
+
+	if !dependenciesEqual(predicted, gold) {
+		t.Error("beam search at width 1 did not reproduce the oracle's own derivation")
+	}
+}
+
+// TestTrainBeamEarlyUpdateOnLabelMismatch checks that TrainBeam fires
+// its early update as soon as the guide under training picks a wrong
+// label, even though that leaves the stack and buffer shaped exactly
+// like the gold path.
+func TestTrainBeamEarlyUpdateOnLabelMismatch(t *testing.T) {
+	tokens := []conllx.Token{*conllx.NewToken(), *conllx.NewToken(), *conllx.NewToken()}
+
+	goldConfig, err := NewConfiguration(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Gold derivation: SHIFT, SHIFT, LEFT_ARC(nsubj), LEFT_ARC(dobj),
+	// RIGHT_ARC(root), SHIFT. Every token gets a gold head, so the
+	// oracle follows this derivation and its first arc, the nsubj
+	// LEFT_ARC at step 2, is the first one the guide mislabels.
+	asShift{}.Apply(&goldConfig)
+	asShift{}.Apply(&goldConfig)
+	asLeftArc{"nsubj"}.Apply(&goldConfig)
+	asLeftArc{"dobj"}.Apply(&goldConfig)
+	asRightArc{"root"}.Apply(&goldConfig)
+	asShift{}.Apply(&goldConfig)
+
+	gold := goldConfig.Dependencies()
+	oracle := NewArcStandardOracle(gold)
+
+	// wrongLabelGuide mimics the oracle's derivation everywhere except
+	// that it attaches with the wrong relation.
+	wrongLabelGuide := &oracleScoringGuide{oracle: trainingMistakeGuide{oracle}}
+
+	parser := NewBeamParser(NewArcStandard(), wrongLabelGuide, 1)
+
+	var sawEarlyUpdateAtLabelMismatch bool
+	_, _, early, err := parser.TrainBeam(tokens, oracle, func(step int, goldInBeam bool, beamBest DependencySet) bool {
+		if step == 2 && !goldInBeam {
+			sawEarlyUpdateAtLabelMismatch = true
+		}
+
+		return !goldInBeam
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !early {
+		t.Error("TrainBeam did not report an early update for a wrong-label derivation")
+	}
+
+	if !sawEarlyUpdateAtLabelMismatch {
+		t.Error("TrainBeam did not detect gold falling off the beam at the mislabeled step")
+	}
+}
+
+// trainingMistakeGuide wraps a Guide, replacing any LEFT-ARC/RIGHT-ARC
+// it would pick with one that carries a different, wrong relation --
+// modelling a guide under training that gets every attachment
+// decision right but every label wrong.
+type trainingMistakeGuide struct {
+	Guide
+}
+
+func (g trainingMistakeGuide) BestTransition(c *Configuration) Transition {
+	switch t := g.Guide.BestTransition(c).(type) {
+	case asLeftArc:
+		return asLeftArc{t.relation + "-wrong"}
+	case asRightArc:
+		return asRightArc{t.relation + "-wrong"}
+	default:
+		return t
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2015 The dpar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package system
+
+import (
+	"sort"
+	"strings"
+)
+
+// liftedRelationSep separates a lifted arc's own relation from the
+// relation its skipped-over head had to its own head, e.g.
+// "obj|pmod" for an object that was lifted across a preposition.
+const liftedRelationSep = "|"
+
+// Pseudoprojectivize rewrites gold's non-projective arcs into
+// projective ones that a projective-only transition system such as
+// ArcStandard or ArcEager can still learn from: a non-projective
+// dependent is repeatedly lifted onto its head's head, and its
+// relation is rewritten to "relation|headRelation" so that
+// Deprojectivize can later find its way back down to the true head.
+// Lifting repeats until every arc is projective, which terminates
+// because each lift strictly shortens the distance to the root.
+//
+// Dependents are visited in sorted token order rather than Go's
+// randomized map order: a pass can both read and rewrite heads for
+// dependents that are themselves another dependent's head, so visiting
+// them in a fixed order is what makes the lifted tree reproducible
+// across runs instead of depending on iteration order.
+func Pseudoprojectivize(gold DependencySet) DependencySet {
+	heads := gold.CreateDependentHeadMapping()
+
+	dependents := make([]uint, 0, len(heads))
+	for dependent := range heads {
+		dependents = append(dependents, dependent)
+	}
+	sort.Slice(dependents, func(i, j int) bool { return dependents[i] < dependents[j] })
+
+	for lifted := true; lifted; {
+		lifted = false
+
+		for _, dependent := range dependents {
+			dep := heads[dependent]
+
+			if isProjectiveArc(dep.Head, dependent, heads) {
+				continue
+			}
+
+			skipped, ok := heads[dep.Head]
+			if !ok {
+				// dep.Head is the root; there is nowhere higher to lift to.
+				continue
+			}
+
+			heads[dependent] = Dependency{
+				Head:      skipped.Head,
+				Relation:  dep.Relation + liftedRelationSep + skipped.Relation,
+				Dependant: dependent,
+			}
+			lifted = true
+		}
+	}
+
+	return dependencySetFromHeads(heads)
+}
+
+// Deprojectivize undoes Pseudoprojectivize on a parser's predicted
+// dependencies. A relation of the form "relation|headRelation1|...|headRelationN"
+// marks an arc that training lifted once per headRelation segment, in
+// the order the lifts happened, so the dependent is walked back down
+// one level per segment -- taken last-lifted first -- to undo them in
+// reverse: at each step the closest descendant of the current head
+// whose own relation to it is that segment becomes the new current
+// head, and the token is finally re-attached there under its original
+// (first-segment) relation.
+func Deprojectivize(predicted DependencySet) DependencySet {
+	heads := predicted.CreateDependentHeadMapping()
+
+	for dependent, dep := range heads {
+		segments := strings.Split(dep.Relation, liftedRelationSep)
+		if len(segments) == 1 {
+			continue
+		}
+
+		head := dep.Head
+		for i := len(segments) - 1; i >= 1; i-- {
+			child, ok := findLiftedHead(head, segments[i], dependent, heads)
+			if !ok {
+				break
+			}
+
+			head = child
+		}
+
+		heads[dependent] = Dependency{Head: head, Relation: segments[0], Dependant: dependent}
+	}
+
+	return dependencySetFromHeads(heads)
+}
+
+// findLiftedHead searches ancestor's children for the one that was
+// skipped over when dependent was lifted onto ancestor -- a child
+// whose relation to ancestor is headRelation. Ancestor may have more
+// than one such child (e.g. two coordinated PPs with the same
+// relation), so ties are broken deterministically by sentence
+// position: the child closest to dependent, the original lift site,
+// wins.
+func findLiftedHead(ancestor uint, headRelation string, dependent uint, heads map[uint]Dependency) (uint, bool) {
+	best, haveBest := uint(0), false
+	bestDistance := uint(0)
+
+	for token, dep := range heads {
+		if dep.Head != ancestor || dep.Relation != headRelation {
+			continue
+		}
+
+		distance := distance(token, dependent)
+		if !haveBest || distance < bestDistance || (distance == bestDistance && token < best) {
+			best, bestDistance, haveBest = token, distance, true
+		}
+	}
+
+	return best, haveBest
+}
+
+// distance returns the absolute difference between two token
+// positions.
+func distance(a, b uint) uint {
+	if a < b {
+		return b - a
+	}
+
+	return a - b
+}
+
+// isProjectiveArc reports whether every token strictly between head
+// and dependent in sentence order is a descendant of head, which is
+// what makes the arc (head, dependent) drawable without crossing
+// lines.
+func isProjectiveArc(head, dependent uint, heads map[uint]Dependency) bool {
+	lo, hi := head, dependent
+	if dependent < head {
+		lo, hi = dependent, head
+	}
+
+	for token := lo + 1; token < hi; token++ {
+		if !isDescendant(token, head, heads) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isDescendant reports whether token is ancestor, or a descendant of
+// it, by walking token's head chain up to the root.
+func isDescendant(token, ancestor uint, heads map[uint]Dependency) bool {
+	for {
+		if token == ancestor {
+			return true
+		}
+
+		dep, ok := heads[token]
+		if !ok {
+			return token == ancestor
+		}
+
+		token = dep.Head
+	}
+}
+
+// dependencySetFromHeads rebuilds a DependencySet from a
+// dependent-to-head mapping, the inverse of CreateDependentHeadMapping.
+func dependencySetFromHeads(heads map[uint]Dependency) DependencySet {
+	dependencies := make([]Dependency, 0, len(heads))
+	for dependent, dep := range heads {
+		dependencies = append(dependencies, Dependency{Head: dep.Head, Relation: dep.Relation, Dependant: dependent})
+	}
+
+	return NewDependencySet(dependencies)
+}
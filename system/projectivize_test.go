@@ -0,0 +1,154 @@
+// Copyright 2015 The dpar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package system
+
+import (
+	"testing"
+)
+
+// TestFindLiftedHeadNearestSibling checks that, when a head has more
+// than one child sharing the relation Deprojectivize is looking for
+// (e.g. two coordinated PPs labeled the same dependent relation),
+// findLiftedHead picks the one closest to the original lift site
+// instead of an arbitrary map-iteration-order winner.
+func TestFindLiftedHeadNearestSibling(t *testing.T) {
+	heads := map[uint]Dependency{
+		3: {Head: 2, Relation: "dep", Dependant: 3},
+		7: {Head: 2, Relation: "dep", Dependant: 7},
+	}
+
+	if got, ok := findLiftedHead(2, "dep", 1, heads); !ok || got != 3 {
+		t.Errorf("findLiftedHead(dependent=1) = (%d, %v), want (3, true)", got, ok)
+	}
+
+	if got, ok := findLiftedHead(2, "dep", 9, heads); !ok || got != 7 {
+		t.Errorf("findLiftedHead(dependent=9) = (%d, %v), want (7, true)", got, ok)
+	}
+}
+
+// TestFindLiftedHeadTieBreaksOnLowestToken pins the tie-break when two
+// candidates are equidistant from the lift site: the lower token
+// index wins, so the result does not depend on map iteration order.
+func TestFindLiftedHeadTieBreaksOnLowestToken(t *testing.T) {
+	heads := map[uint]Dependency{
+		2: {Head: 2, Relation: "dep", Dependant: 2},
+		8: {Head: 2, Relation: "dep", Dependant: 8},
+	}
+
+	if got, ok := findLiftedHead(2, "dep", 5, heads); !ok || got != 2 {
+		t.Errorf("findLiftedHead(dependent=5) = (%d, %v), want (2, true)", got, ok)
+	}
+}
+
+// TestPseudoprojectivizeDeprojectivizeRoundTrip builds a small
+// non-projective tree, checks that Pseudoprojectivize lifts its one
+// crossing arc onto a projective one with an encoded label, and that
+// Deprojectivize recovers the original tree exactly.
+func TestPseudoprojectivizeDeprojectivizeRoundTrip(t *testing.T) {
+	gold := NewDependencySet([]Dependency{
+		{Head: 3, Relation: "amod", Dependant: 1},
+		{Head: 0, Relation: "root", Dependant: 2},
+		{Head: 2, Relation: "dep", Dependant: 3},
+		{Head: 2, Relation: "dep2", Dependant: 4},
+	})
+
+	projectivized := Pseudoprojectivize(gold)
+	projHeads := projectivized.CreateDependentHeadMapping()
+
+	lifted, ok := projHeads[1]
+	if !ok {
+		t.Fatal("token 1 missing from pseudoprojectivized tree")
+	}
+
+	if lifted.Head != 2 || lifted.Relation != "amod|dep" {
+		t.Errorf("pseudoprojectivized token 1 = %+v, want Head=2 Relation=\"amod|dep\"", lifted)
+	}
+
+	for dependent, dep := range projHeads {
+		if !isProjectiveArc(dep.Head, dependent, projHeads) {
+			t.Errorf("arc %d -> %d is still non-projective after Pseudoprojectivize", dep.Head, dependent)
+		}
+	}
+
+	deprojectivized := Deprojectivize(projectivized)
+	gotHeads := deprojectivized.CreateDependentHeadMapping()
+	wantHeads := gold.CreateDependentHeadMapping()
+
+	for token, want := range wantHeads {
+		got, ok := gotHeads[token]
+		if !ok || got != want {
+			t.Errorf("deprojectivized token %d = %+v (ok=%v), want %+v", token, got, ok, want)
+		}
+	}
+}
+
+// TestPseudoprojectivizeDeprojectivizeRoundTripChainedLift checks a
+// dependent that crosses two non-projective levels: lifting it once is
+// not enough to make its arc projective, so Pseudoprojectivize lifts it
+// again, chaining a second relation onto the label. Deprojectivize must
+// walk back down both levels, not just the first, to recover the
+// original head and relation.
+func TestPseudoprojectivizeDeprojectivizeRoundTripChainedLift(t *testing.T) {
+	gold := NewDependencySet([]Dependency{
+		{Head: 2, Relation: "rel1", Dependant: 1},
+		{Head: 3, Relation: "rel2", Dependant: 2},
+		{Head: 0, Relation: "rel3", Dependant: 3},
+		{Head: 1, Relation: "rel4", Dependant: 4},
+	})
+
+	projectivized := Pseudoprojectivize(gold)
+	projHeads := projectivized.CreateDependentHeadMapping()
+
+	lifted, ok := projHeads[4]
+	if !ok {
+		t.Fatal("token 4 missing from pseudoprojectivized tree")
+	}
+
+	if lifted.Head != 3 || lifted.Relation != "rel4|rel1|rel2" {
+		t.Errorf("pseudoprojectivized token 4 = %+v, want Head=3 Relation=\"rel4|rel1|rel2\"", lifted)
+	}
+
+	for dependent, dep := range projHeads {
+		if !isProjectiveArc(dep.Head, dependent, projHeads) {
+			t.Errorf("arc %d -> %d is still non-projective after Pseudoprojectivize", dep.Head, dependent)
+		}
+	}
+
+	deprojectivized := Deprojectivize(projectivized)
+	gotHeads := deprojectivized.CreateDependentHeadMapping()
+	wantHeads := gold.CreateDependentHeadMapping()
+
+	for token, want := range wantHeads {
+		got, ok := gotHeads[token]
+		if !ok || got != want {
+			t.Errorf("deprojectivized token %d = %+v (ok=%v), want %+v", token, got, ok, want)
+		}
+	}
+}
+
+// TestPseudoprojectivizeDeterministic checks that Pseudoprojectivize
+// produces byte-identical results across repeated runs on the same
+// input, pinning the fix that replaced a live map range (randomized
+// iteration order) with a fixed, sorted visiting order.
+func TestPseudoprojectivizeDeterministic(t *testing.T) {
+	gold := NewDependencySet([]Dependency{
+		{Head: 2, Relation: "rel1", Dependant: 1},
+		{Head: 3, Relation: "rel2", Dependant: 2},
+		{Head: 0, Relation: "rel3", Dependant: 3},
+		{Head: 1, Relation: "rel4", Dependant: 4},
+	})
+
+	first := Pseudoprojectivize(gold).CreateDependentHeadMapping()
+
+	for i := 0; i < 20; i++ {
+		again := Pseudoprojectivize(gold).CreateDependentHeadMapping()
+
+		for token, want := range first {
+			if got := again[token]; got != want {
+				t.Fatalf("run %d: token %d = %+v, want %+v (nondeterministic result)", i, token, got, want)
+			}
+		}
+	}
+}